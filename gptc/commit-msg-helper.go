@@ -1,42 +1,126 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"text/template"
 )
 
 const (
-	defaultGPTModel    = "o4-mini"
-	defaultGeminiModel = "gemini-2.5-pro-exp-03-25"
-	defaultProvider    = string(OpenAI)
+	defaultGPTModel       = "o4-mini"
+	defaultGeminiModel    = "gemini-2.5-pro-exp-03-25"
+	defaultAnthropicModel = "claude-3-5-sonnet-latest"
+	defaultProvider       = string(OpenAI)
+	// defaultMaxDiffTokens is the estimated-token budget above which a diff is
+	// split into per-file hunks and summarized piecewise before being reduced.
+	defaultMaxDiffTokens = 6000
 )
 
-// Prompt templates
+// Default prompt templates, rendered with text/template against a templateData.
+// A repo can override either via the commit_template/pr_template keys in
+// .dvag-helper.yaml.
 const (
-	commitPromptTemplate = `Do not use ` + "```" + `.
+	defaultCommitPromptTemplate = `Do not use ` + "```" + `.
  Create a CONVENTIONAL commit message for this git diff with the structure: <type>[optional scope]: <description>
+{{- if .Types }}
+Allowed types: {{ join .Types ", " }}
+{{- end }}
+{{- if .Scopes }}
+Allowed scopes: {{ join .Scopes ", " }}
+{{- end }}
+{{- if .TicketID }}
+Reference ticket {{ .TicketID }} in the description.
+{{- end }}
+{{- if .Extra }}
+{{ .Extra }}
+{{- end }}
 Ignore formatting and whitespace changes and focus on the big picture.
-%s`
+{{ .Diff }}`
 
-	prPromptTemplate = `Do not use ` + "```" + `.
+	defaultPRPromptTemplate = `Do not use ` + "```" + `.
 Create a pull request description for these changes.
 Include: 1) A clear title, 2) What changes were made, 3) Why these changes were necessary,
 and 4) Any testing considerations.
+{{- if .Language }}
+Write the description in {{ .Language }}.
+{{- end }}
+{{- if .TicketID }}
+Reference ticket {{ .TicketID }} in the description.
+{{- end }}
+{{- if .Extra }}
+{{ .Extra }}
+{{- end }}
 Ignore formatting and whitespace changes and focus on the big picture.
-Write the description in GERMAN!!!
 Format with markdown:
-%s`
+{{ .Diff }}`
+
+	// defaultReduceCommitPromptTemplate and defaultReducePRPromptTemplate merge
+	// the per-chunk summaries produced when a diff exceeds --max-diff-tokens
+	// into one final message. They're rendered with the same templateData as
+	// the per-chunk prompts so config constraints (language, types, scopes,
+	// ticket ID) survive chunking.
+	defaultReduceCommitPromptTemplate = `Do not use ` + "```" + `.
+Merge these partial commit message summaries into a single, coherent CONVENTIONAL commit message with the structure: <type>[optional scope]: <description>
+{{- if .Types }}
+Allowed types: {{ join .Types ", " }}
+{{- end }}
+{{- if .Scopes }}
+Allowed scopes: {{ join .Scopes ", " }}
+{{- end }}
+{{- if .TicketID }}
+Reference ticket {{ .TicketID }} in the description.
+{{- end }}
+Partial summaries:
+{{ .Diff }}`
+
+	defaultReducePRPromptTemplate = `Do not use ` + "```" + `.
+Merge these partial pull request summaries into a single, coherent PR description.
+{{- if .Language }}
+Write the description in {{ .Language }}.
+{{- end }}
+{{- if .TicketID }}
+Reference ticket {{ .TicketID }} in the description.
+{{- end }}
+Partial summaries:
+{{ .Diff }}`
 )
 
+// templateData is the set of variables exposed to commit/PR prompt templates.
+type templateData struct {
+	Diff     string
+	Branch   string
+	Types    []string
+	Scopes   []string
+	TicketID string
+	Language string
+	Extra    string
+}
+
+func renderPrompt(tmplText string, data templateData) (string, error) {
+	tmpl, err := template.New("prompt").Funcs(template.FuncMap{"join": strings.Join}).Parse(tmplText)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse prompt template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template: %w", err)
+	}
+	return buf.String(), nil
+}
+
 type Mode string
 
 const (
@@ -56,8 +140,11 @@ type Message struct {
 type ProviderEnum string
 
 const (
-	OpenAI ProviderEnum = "openai"
-	Google ProviderEnum = "google"
+	OpenAI           ProviderEnum = "openai"
+	Google           ProviderEnum = "google"
+	OpenAICompatible ProviderEnum = "openai-compatible"
+	Ollama           ProviderEnum = "ollama"
+	Anthropic        ProviderEnum = "anthropic"
 )
 
 func ProviderEnumFromString(s string) ProviderEnum {
@@ -66,6 +153,12 @@ func ProviderEnumFromString(s string) ProviderEnum {
 		return OpenAI
 	case "google":
 		return Google
+	case "openai-compatible":
+		return OpenAICompatible
+	case "ollama":
+		return Ollama
+	case "anthropic":
+		return Anthropic
 	default:
 		fmt.Fprintf(os.Stderr, "[WARN] invalid provider: %s", s)
 		fmt.Fprintf(os.Stderr, "[INFO] defaulting to provider: %s", Google)
@@ -73,16 +166,64 @@ func ProviderEnumFromString(s string) ProviderEnum {
 	}
 }
 
-// config holds the application configuration
+// config holds the application configuration, assembled from built-in
+// defaults, .dvag-helper.yaml, and finally CLI flags.
 type config struct {
-	Model    string
-	Mode     Mode
-	Provider ProviderEnum
+	Model             string
+	Mode              Mode
+	Provider          ProviderEnum
+	BaseURL           string
+	MaxDiffTokens     int
+	Language          string
+	Types             []string
+	Scopes            []string
+	ExtraInstructions string
+	CommitTemplate    string
+	PRTemplate        string
+	Branch            string
+	TicketID          string
+	SystemInstruction string
+	Examples          []Message
+	Temperature       *float64
+	TopP              *float64
+	TopK              *int
+	MaxOutputTokens   *int
+	Stream            bool
 }
 
 // Provider defines the interface for AI providers
 type Provider interface {
 	GenerateMessage(prompt string) (string, error)
+	// GenerateMessageStream is the streaming equivalent of GenerateMessage: it
+	// returns a channel of incremental tokens as they arrive over
+	// server-sent events, plus a channel that carries at most one error.
+	// Both channels are closed once the stream ends.
+	GenerateMessageStream(prompt string) (<-chan string, <-chan error)
+	// Embed returns one embedding vector per input text, used to cluster
+	// diff hunks that are too large to summarize in a single prompt.
+	Embed(texts []string) ([][]float32, error)
+}
+
+// readSSE scans resp.Body for "data: ..." lines, the framing used by every
+// SSE-based streaming API this tool talks to, and invokes onData with the
+// payload of each one. It stops at the first error or once onData reports done.
+func readSSE(resp *http.Response, onData func(data string) (done bool, err error)) error {
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		done, err := onData(data)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+	return scanner.Err()
 }
 
 // OpenAIProvider implements the Provider interface for OpenAI
@@ -90,11 +231,22 @@ type OpenAIProvider struct {
 	APIKey string
 	APIURL string
 	Model  string
+
+	// SystemInstruction and Examples are sent as additional system/user/
+	// assistant messages ahead of the prompt for system-level steering and
+	// few-shot priming. Temperature/MaxOutputTokens are optional.
+	SystemInstruction string
+	Examples          []Message
+	Temperature       *float64
+	MaxOutputTokens   *int
 }
 
 type OpenAIRequestBody struct {
-	Model    string    `json:"model"`
-	Messages []Message `json:"messages"`
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	Temperature *float64  `json:"temperature,omitempty"`
+	MaxTokens   *int      `json:"max_tokens,omitempty"`
+	Stream      bool      `json:"stream,omitempty"`
 }
 
 type OpenAIResponseBody struct {
@@ -105,21 +257,233 @@ type OpenAIResponseBody struct {
 	} `json:"choices"`
 }
 
+// OpenAIStreamChunk is one "data: {...}" frame of an OpenAI/OpenAI-compatible
+// streamed chat completion.
+type OpenAIStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+}
+
+// generateOpenAIMessageStream performs a streaming chat completion request
+// shared by OpenAIProvider and OpenAICompatibleProvider, which only differ in
+// the Authorization header.
+func generateOpenAIMessageStream(apiURL, apiKey string, requestBody OpenAIRequestBody) (<-chan string, <-chan error) {
+	tokens := make(chan string)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(tokens)
+		defer close(errs)
+
+		requestBody.Stream = true
+		jsonData, err := json.Marshal(requestBody)
+		if err != nil {
+			errs <- fmt.Errorf("failed to marshal request: %w", err)
+			return
+		}
+
+		req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
+		if err != nil {
+			errs <- fmt.Errorf("failed to create request: %w", err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+
+		client := &http.Client{}
+		resp, err := client.Do(req)
+		if err != nil {
+			errs <- fmt.Errorf("failed to make API request: %w", err)
+			return
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			errs <- fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+			return
+		}
+
+		err = readSSE(resp, func(data string) (bool, error) {
+			if data == "[DONE]" {
+				return true, nil
+			}
+			var chunk OpenAIStreamChunk
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				return false, fmt.Errorf("failed to decode stream chunk: %w", err)
+			}
+			if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+				tokens <- chunk.Choices[0].Delta.Content
+			}
+			return false, nil
+		})
+		if err != nil {
+			errs <- fmt.Errorf("failed to read stream: %w", err)
+		}
+	}()
+
+	return tokens, errs
+}
+
+const defaultOpenAIEmbeddingModel = "text-embedding-3-small"
+
+type OpenAIEmbeddingRequestBody struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type OpenAIEmbeddingResponseBody struct {
+	Data []struct {
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// OpenAICompatibleProvider implements the Provider interface for any
+// OpenAI-compatible HTTP backend (Ollama, LocalAI, llama.cpp server, vLLM, ...).
+// Unlike OpenAIProvider, the API key is optional since most local backends
+// don't require authentication.
+type OpenAICompatibleProvider struct {
+	APIKey string
+	APIURL string
+	Model  string
+
+	SystemInstruction string
+	Examples          []Message
+	Temperature       *float64
+	MaxOutputTokens   *int
+}
+
+func (p *OpenAICompatibleProvider) GenerateMessage(prompt string) (string, error) {
+	requestBody := OpenAIRequestBody{
+		Model:       p.Model,
+		Messages:    buildOpenAIMessages(p.SystemInstruction, p.Examples, prompt),
+		Temperature: p.Temperature,
+		MaxTokens:   p.MaxOutputTokens,
+	}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", p.APIURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if p.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.APIKey)
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to make API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var response OpenAIResponseBody
+	if err = json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(response.Choices) == 0 {
+		return "", fmt.Errorf("no choices in API response")
+	}
+
+	return response.Choices[0].Message.Content, nil
+}
+
+func (p *OpenAICompatibleProvider) GenerateMessageStream(prompt string) (<-chan string, <-chan error) {
+	requestBody := OpenAIRequestBody{
+		Model:       p.Model,
+		Messages:    buildOpenAIMessages(p.SystemInstruction, p.Examples, prompt),
+		Temperature: p.Temperature,
+		MaxTokens:   p.MaxOutputTokens,
+	}
+	return generateOpenAIMessageStream(p.APIURL, p.APIKey, requestBody)
+}
+
+func (p *OpenAICompatibleProvider) Embed(texts []string) ([][]float32, error) {
+	requestBody := OpenAIEmbeddingRequestBody{
+		Model: defaultOpenAIEmbeddingModel,
+		Input: texts,
+	}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal embedding request: %w", err)
+	}
+
+	embedURL := strings.TrimSuffix(p.APIURL, "/chat/completions") + "/embeddings"
+	req, err := http.NewRequest("POST", embedURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if p.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.APIKey)
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make embedding API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var response OpenAIEmbeddingResponseBody
+	if err = json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding response: %w", err)
+	}
+
+	embeddings := make([][]float32, len(response.Data))
+	for i, d := range response.Data {
+		embeddings[i] = d.Embedding
+	}
+	return embeddings, nil
+}
+
 // --- Google Gemini Provider ---
 
 type GoogleGeminiProvider struct {
 	APIKey  string
 	BaseURL string
 	Model   string
+
+	// SystemInstruction and Examples provide system-level steering and
+	// few-shot priming; Temperature/TopP/TopK/MaxOutputTokens map onto
+	// generationConfig. All are optional.
+	SystemInstruction string
+	Examples          []Message
+	Temperature       *float64
+	TopP              *float64
+	TopK              *int
+	MaxOutputTokens   *int
 }
 
 type GeminiRequestBody struct {
-	Contents []GeminiContent `json:"contents"`
-	// GenerationConfig *GeminiGenerationConfig `json:"generationConfig,omitempty"`
+	Contents          []GeminiContent         `json:"contents"`
+	SystemInstruction *GeminiContent          `json:"systemInstruction,omitempty"`
+	GenerationConfig  *GeminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type GeminiGenerationConfig struct {
+	Temperature     *float64 `json:"temperature,omitempty"`
+	TopP            *float64 `json:"topP,omitempty"`
+	TopK            *int     `json:"topK,omitempty"`
+	MaxOutputTokens *int     `json:"maxOutputTokens,omitempty"`
 }
 type GeminiContent struct {
 	Parts []GeminiPart `json:"parts"`
-	// Role string // Optional: "user" or "model" - defaults to user if omitted in first turn
+	Role  string       `json:"role,omitempty"` // "user" or "model" on conversation turns; omitted for systemInstruction and embedding content
 }
 type GeminiPart struct {
 	Text string `json:"text"`
@@ -143,19 +507,55 @@ type GeminiPromptFeedback struct {
 	SafetyRatings []GeminiSafetyRating `json:"safetyRatings"`
 }
 
+const defaultGeminiEmbeddingModel = "text-embedding-004"
+
+type GeminiBatchEmbedRequestBody struct {
+	Requests []GeminiEmbedContentRequest `json:"requests"`
+}
+type GeminiEmbedContentRequest struct {
+	Model   string        `json:"model"`
+	Content GeminiContent `json:"content"`
+}
+type GeminiBatchEmbedResponseBody struct {
+	Embeddings []struct {
+		Values []float32 `json:"values"`
+	} `json:"embeddings"`
+}
+
 func (p *GoogleGeminiProvider) GenerateMessage(prompt string) (string, error) {
 	apiURL := fmt.Sprintf("%s/v1beta/models/%s:generateContent?key=%s", p.BaseURL, p.Model, p.APIKey)
 
-	requestBody := GeminiRequestBody{
-		Contents: []GeminiContent{
-			{
-				Parts: []GeminiPart{
-					{
-						Text: prompt,
-					},
-				},
-			},
-		},
+	var contents []GeminiContent
+	for _, example := range p.Examples {
+		role := "user"
+		if example.Role == "assistant" {
+			role = "model"
+		}
+		contents = append(contents, GeminiContent{
+			Role:  role,
+			Parts: []GeminiPart{{Text: example.Content}},
+		})
+	}
+	contents = append(contents, GeminiContent{
+		Role:  "user",
+		Parts: []GeminiPart{{Text: prompt}},
+	})
+
+	requestBody := GeminiRequestBody{Contents: contents}
+
+	if p.SystemInstruction != "" {
+		requestBody.SystemInstruction = &GeminiContent{
+			Parts: []GeminiPart{{Text: p.SystemInstruction}},
+		}
+	}
+
+	if p.Temperature != nil || p.TopP != nil || p.TopK != nil || p.MaxOutputTokens != nil {
+		requestBody.GenerationConfig = &GeminiGenerationConfig{
+			Temperature:     p.Temperature,
+			TopP:            p.TopP,
+			TopK:            p.TopK,
+			MaxOutputTokens: p.MaxOutputTokens,
+		}
 	}
 
 	jsonData, err := json.Marshal(requestBody)
@@ -231,15 +631,190 @@ func (p *GoogleGeminiProvider) GenerateMessage(prompt string) (string, error) {
 
 	return generatedText.String(), nil
 }
+
+func (p *GoogleGeminiProvider) GenerateMessageStream(prompt string) (<-chan string, <-chan error) {
+	tokens := make(chan string)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(tokens)
+		defer close(errs)
+
+		apiURL := fmt.Sprintf("%s/v1beta/models/%s:streamGenerateContent?alt=sse&key=%s", p.BaseURL, p.Model, p.APIKey)
+
+		var contents []GeminiContent
+		for _, example := range p.Examples {
+			role := "user"
+			if example.Role == "assistant" {
+				role = "model"
+			}
+			contents = append(contents, GeminiContent{
+				Role:  role,
+				Parts: []GeminiPart{{Text: example.Content}},
+			})
+		}
+		contents = append(contents, GeminiContent{
+			Role:  "user",
+			Parts: []GeminiPart{{Text: prompt}},
+		})
+
+		requestBody := GeminiRequestBody{Contents: contents}
+
+		if p.SystemInstruction != "" {
+			requestBody.SystemInstruction = &GeminiContent{
+				Parts: []GeminiPart{{Text: p.SystemInstruction}},
+			}
+		}
+
+		if p.Temperature != nil || p.TopP != nil || p.TopK != nil || p.MaxOutputTokens != nil {
+			requestBody.GenerationConfig = &GeminiGenerationConfig{
+				Temperature:     p.Temperature,
+				TopP:            p.TopP,
+				TopK:            p.TopK,
+				MaxOutputTokens: p.MaxOutputTokens,
+			}
+		}
+
+		jsonData, err := json.Marshal(requestBody)
+		if err != nil {
+			errs <- fmt.Errorf("failed to marshal gemini request: %w", err)
+			return
+		}
+
+		req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
+		if err != nil {
+			errs <- fmt.Errorf("failed to create gemini request: %w", err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		client := &http.Client{}
+		resp, err := client.Do(req)
+		if err != nil {
+			errs <- fmt.Errorf("failed to make gemini API request: %w", err)
+			return
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			var errorResponse struct {
+				Error struct {
+					Code    int    `json:"code"`
+					Message string `json:"message"`
+					Status  string `json:"status"`
+				} `json:"error"`
+			}
+			if json.Unmarshal(bodyBytes, &errorResponse) == nil && errorResponse.Error.Message != "" {
+				errs <- fmt.Errorf("gemini API error (%d %s): %s", errorResponse.Error.Code, errorResponse.Error.Status, errorResponse.Error.Message)
+			} else {
+				errs <- fmt.Errorf("gemini API request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+			}
+			return
+		}
+
+		err = readSSE(resp, func(data string) (bool, error) {
+			var chunk GeminiResponseBody
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				return false, fmt.Errorf("failed to decode gemini stream chunk: %w", err)
+			}
+			if len(chunk.Candidates) == 0 {
+				return false, nil
+			}
+			candidate := chunk.Candidates[0]
+			// SAFETY (and any reason other than STOP or MAX_TOKENS) can arrive mid-stream once
+			// generation is cut short; surface it instead of silently
+			// truncating the accumulated message.
+			if candidate.FinishReason != "" && candidate.FinishReason != "STOP" && candidate.FinishReason != "MAX_TOKENS" {
+				return false, fmt.Errorf("gemini generation finished due to %s", candidate.FinishReason)
+			}
+			if candidate.Content == nil {
+				return false, nil
+			}
+			for _, part := range candidate.Content.Parts {
+				if part.Text != "" {
+					tokens <- part.Text
+				}
+			}
+			return false, nil
+		})
+		if err != nil {
+			errs <- err
+		}
+	}()
+
+	return tokens, errs
+}
+
+func (p *GoogleGeminiProvider) Embed(texts []string) ([][]float32, error) {
+	apiURL := fmt.Sprintf("%s/v1beta/models/%s:batchEmbedContents?key=%s", p.BaseURL, defaultGeminiEmbeddingModel, p.APIKey)
+
+	requests := make([]GeminiEmbedContentRequest, len(texts))
+	for i, text := range texts {
+		requests[i] = GeminiEmbedContentRequest{
+			Model:   "models/" + defaultGeminiEmbeddingModel,
+			Content: GeminiContent{Parts: []GeminiPart{{Text: text}}},
+		}
+	}
+	requestBody := GeminiBatchEmbedRequestBody{Requests: requests}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal gemini embedding request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gemini embedding request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make gemini embedding API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gemini embedding response body: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("gemini embedding API request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var response GeminiBatchEmbedResponseBody
+	if err = json.Unmarshal(bodyBytes, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode gemini embedding response: %w\nResponse body: %s", err, string(bodyBytes))
+	}
+
+	embeddings := make([][]float32, len(response.Embeddings))
+	for i, e := range response.Embeddings {
+		embeddings[i] = e.Values
+	}
+	return embeddings, nil
+}
+
+// buildOpenAIMessages assembles the chat messages array: an optional system
+// instruction, then any few-shot examples, then the actual prompt.
+func buildOpenAIMessages(systemInstruction string, examples []Message, prompt string) []Message {
+	var messages []Message
+	if systemInstruction != "" {
+		messages = append(messages, Message{Role: "system", Content: systemInstruction})
+	}
+	messages = append(messages, examples...)
+	messages = append(messages, Message{Role: "user", Content: prompt})
+	return messages
+}
+
 func (p *OpenAIProvider) GenerateMessage(prompt string) (string, error) {
 	requestBody := OpenAIRequestBody{
-		Model: p.Model,
-		Messages: []Message{
-			{
-				Role:    "user",
-				Content: prompt,
-			},
-		},
+		Model:       p.Model,
+		Messages:    buildOpenAIMessages(p.SystemInstruction, p.Examples, prompt),
+		Temperature: p.Temperature,
+		MaxTokens:   p.MaxOutputTokens,
 	}
 
 	jsonData, err := json.Marshal(requestBody)
@@ -274,90 +849,1005 @@ func (p *OpenAIProvider) GenerateMessage(prompt string) (string, error) {
 	return response.Choices[0].Message.Content, nil
 }
 
-// NewProvider creates and returns a provider based on the configuration
-func NewProvider(cfg *config) Provider {
-	switch cfg.Provider {
-	case OpenAI:
-		key, ok := os.LookupEnv("OPENAI_API_KEY")
-
-		if !ok || key == "" {
-			log.Fatal("API key not set. Please set the OPENAI_API_KEY environment variable.")
-		}
-
-		model := defaultGPTModel
-		if cfg.Model != "" {
-			model = cfg.Model
-		}
-		return &OpenAIProvider{
-			APIKey: key,
-			APIURL: "https://api.openai.com/v1/chat/completions",
-			Model:  model,
-		}
-	case Google:
-		key, ok := os.LookupEnv("GEMINI_API_KEY")
-		if !ok || key == "" {
-			log.Fatal("API key not set. Please set the GEMINI_API_KEY environment variable.")
-		}
-		model := defaultGeminiModel
-		if cfg.Model != "" && cfg.Model != defaultGPTModel {
-			model = cfg.Model
-		}
-		return &GoogleGeminiProvider{
-			APIKey:  key,
-			BaseURL: "https://generativelanguage.googleapis.com",
-			Model:   model,
-		}
-	default:
-		log.Fatalf("unsupported provider: %s", cfg.Provider)
-		return nil
+func (p *OpenAIProvider) GenerateMessageStream(prompt string) (<-chan string, <-chan error) {
+	requestBody := OpenAIRequestBody{
+		Model:       p.Model,
+		Messages:    buildOpenAIMessages(p.SystemInstruction, p.Examples, prompt),
+		Temperature: p.Temperature,
+		MaxTokens:   p.MaxOutputTokens,
 	}
+	return generateOpenAIMessageStream(p.APIURL, p.APIKey, requestBody)
 }
 
-func getGitDiff() (string, error) {
-	stdinStat, _ := os.Stdin.Stat()
-	if (stdinStat.Mode() & os.ModeCharDevice) == 0 {
-		stdinBytes, err := os.ReadFile(os.Stdin.Name())
-		if err != nil {
-			return "", fmt.Errorf("failed to read from stdin: %w", err)
-		}
-
-		diff := strings.TrimSpace(string(stdinBytes))
-		if diff != "" {
-			return diff, nil
-		}
-	}
-	fmt.Fprintf(os.Stderr, "[WARN] No input from stdin, checking for staged changes...\n")
-	cmd := exec.Command("git", "diff", "--staged")
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("failed to get git diff: %w", err)
+func (p *OpenAIProvider) Embed(texts []string) ([][]float32, error) {
+	requestBody := OpenAIEmbeddingRequestBody{
+		Model: defaultOpenAIEmbeddingModel,
+		Input: texts,
 	}
 
-	diff := strings.TrimSpace(out.String())
-	if diff == "" {
-		return "", fmt.Errorf("no staged changes found")
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal embedding request: %w", err)
 	}
-	return diff, nil
-}
 
-func generateMessage(cfg *config, gitDiff string) (string, error) {
-	var prompt string
+	req, err := http.NewRequest("POST", "https://api.openai.com/v1/embeddings", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.APIKey)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make embedding API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var response OpenAIEmbeddingResponseBody
+	if err = json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding response: %w", err)
+	}
+
+	embeddings := make([][]float32, len(response.Data))
+	for i, d := range response.Data {
+		embeddings[i] = d.Embedding
+	}
+	return embeddings, nil
+}
+
+// --- Anthropic Claude Provider ---
+
+type AnthropicProvider struct {
+	APIKey string
+	APIURL string
+	Model  string
+
+	// MaxOutputTokens is optional; Anthropic's API requires max_tokens on
+	// every request, so anthropicMaxTokens is used when it's nil.
+	// SystemInstruction/Examples/Temperature are not yet wired through for
+	// this provider; NewProvider warns if a user sets them with
+	// --provider=anthropic.
+	MaxOutputTokens *int
+}
+
+// anthropicMaxTokens is the max_tokens sent when MaxOutputTokens isn't set.
+const anthropicMaxTokens = 1024
+
+type AnthropicRequestBody struct {
+	Model     string             `json:"model"`
+	MaxTokens int                `json:"max_tokens"`
+	Messages  []AnthropicMessage `json:"messages"`
+	Stream    bool               `json:"stream,omitempty"`
+}
+
+type AnthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type AnthropicResponseBody struct {
+	Content []struct {
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+func (p *AnthropicProvider) GenerateMessage(prompt string) (string, error) {
+	maxTokens := anthropicMaxTokens
+	if p.MaxOutputTokens != nil {
+		maxTokens = *p.MaxOutputTokens
+	}
+	requestBody := AnthropicRequestBody{
+		Model:     p.Model,
+		MaxTokens: maxTokens,
+		Messages: []AnthropicMessage{
+			{
+				Role:    "user",
+				Content: prompt,
+			},
+		},
+	}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal anthropic request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", p.APIURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create anthropic request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", p.APIKey)
+	req.Header.Set("anthropic-version", "2023-06-01")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to make anthropic API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read anthropic response body: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("anthropic API request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var response AnthropicResponseBody
+	if err = json.Unmarshal(bodyBytes, &response); err != nil {
+		return "", fmt.Errorf("failed to decode anthropic response: %w\nResponse body: %s", err, string(bodyBytes))
+	}
+
+	if len(response.Content) == 0 {
+		return "", fmt.Errorf("no content in anthropic API response. Body: %s", string(bodyBytes))
+	}
+
+	return response.Content[0].Text, nil
+}
+
+// anthropicStreamEvent covers the fields we care about across Anthropic's
+// "message_start"/"content_block_delta"/"message_stop"/"error" SSE event
+// types; all other event types are ignored.
+type anthropicStreamEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Text string `json:"text"`
+	} `json:"delta"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (p *AnthropicProvider) GenerateMessageStream(prompt string) (<-chan string, <-chan error) {
+	tokens := make(chan string)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(tokens)
+		defer close(errs)
+
+		maxTokens := anthropicMaxTokens
+		if p.MaxOutputTokens != nil {
+			maxTokens = *p.MaxOutputTokens
+		}
+		requestBody := AnthropicRequestBody{
+			Model:     p.Model,
+			MaxTokens: maxTokens,
+			Messages: []AnthropicMessage{
+				{
+					Role:    "user",
+					Content: prompt,
+				},
+			},
+			Stream: true,
+		}
+
+		jsonData, err := json.Marshal(requestBody)
+		if err != nil {
+			errs <- fmt.Errorf("failed to marshal anthropic request: %w", err)
+			return
+		}
+
+		req, err := http.NewRequest("POST", p.APIURL, bytes.NewBuffer(jsonData))
+		if err != nil {
+			errs <- fmt.Errorf("failed to create anthropic request: %w", err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("x-api-key", p.APIKey)
+		req.Header.Set("anthropic-version", "2023-06-01")
+
+		client := &http.Client{}
+		resp, err := client.Do(req)
+		if err != nil {
+			errs <- fmt.Errorf("failed to make anthropic API request: %w", err)
+			return
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			errs <- fmt.Errorf("anthropic API request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+			return
+		}
+
+		err = readSSE(resp, func(data string) (bool, error) {
+			var event anthropicStreamEvent
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				// Anthropic also emits non-JSON "ping" comment lines; ignore them.
+				return false, nil
+			}
+			if event.Type == "error" {
+				if event.Error != nil {
+					return false, fmt.Errorf("anthropic stream error (%s): %s", event.Error.Type, event.Error.Message)
+				}
+				return false, fmt.Errorf("anthropic stream error")
+			}
+			if event.Type == "content_block_delta" && event.Delta.Text != "" {
+				tokens <- event.Delta.Text
+			}
+			return event.Type == "message_stop", nil
+		})
+		if err != nil {
+			errs <- fmt.Errorf("failed to read anthropic stream: %w", err)
+		}
+	}()
+
+	return tokens, errs
+}
+
+func (p *AnthropicProvider) Embed(texts []string) ([][]float32, error) {
+	return nil, fmt.Errorf("anthropic provider does not support embeddings")
+}
+
+// NewProvider creates and returns a provider based on the configuration
+func NewProvider(cfg *config) Provider {
+	switch cfg.Provider {
+	case OpenAI:
+		key, ok := os.LookupEnv("OPENAI_API_KEY")
+
+		if !ok || key == "" {
+			log.Fatal("API key not set. Please set the OPENAI_API_KEY environment variable.")
+		}
+
+		model := defaultGPTModel
+		if cfg.Model != "" {
+			model = cfg.Model
+		}
+		return &OpenAIProvider{
+			APIKey:            key,
+			APIURL:            "https://api.openai.com/v1/chat/completions",
+			Model:             model,
+			SystemInstruction: cfg.SystemInstruction,
+			Examples:          cfg.Examples,
+			Temperature:       cfg.Temperature,
+			MaxOutputTokens:   cfg.MaxOutputTokens,
+		}
+	case Google:
+		key, ok := os.LookupEnv("GEMINI_API_KEY")
+		if !ok || key == "" {
+			log.Fatal("API key not set. Please set the GEMINI_API_KEY environment variable.")
+		}
+		model := defaultGeminiModel
+		if cfg.Model != "" && cfg.Model != defaultGPTModel {
+			model = cfg.Model
+		}
+		return &GoogleGeminiProvider{
+			APIKey:            key,
+			BaseURL:           "https://generativelanguage.googleapis.com",
+			Model:             model,
+			SystemInstruction: cfg.SystemInstruction,
+			Examples:          cfg.Examples,
+			Temperature:       cfg.Temperature,
+			TopP:              cfg.TopP,
+			TopK:              cfg.TopK,
+			MaxOutputTokens:   cfg.MaxOutputTokens,
+		}
+	case OpenAICompatible, Ollama:
+		baseURL := cfg.BaseURL
+		if baseURL == "" {
+			baseURL = os.Getenv("OPENAI_COMPATIBLE_BASE_URL")
+		}
+		if baseURL == "" && cfg.Provider == Ollama {
+			baseURL = "http://localhost:11434/v1"
+		}
+		if baseURL == "" {
+			log.Fatal("base URL not set. Please pass --base-url or set the OPENAI_COMPATIBLE_BASE_URL environment variable.")
+		}
+
+		model := defaultGPTModel
+		if cfg.Model != "" {
+			model = cfg.Model
+		}
+		return &OpenAICompatibleProvider{
+			APIKey:            os.Getenv("OPENAI_COMPATIBLE_API_KEY"),
+			APIURL:            strings.TrimRight(baseURL, "/") + "/chat/completions",
+			Model:             model,
+			SystemInstruction: cfg.SystemInstruction,
+			Examples:          cfg.Examples,
+			Temperature:       cfg.Temperature,
+			MaxOutputTokens:   cfg.MaxOutputTokens,
+		}
+	case Anthropic:
+		key, ok := os.LookupEnv("ANTHROPIC_API_KEY")
+		if !ok || key == "" {
+			log.Fatal("API key not set. Please set the ANTHROPIC_API_KEY environment variable.")
+		}
+		model := defaultAnthropicModel
+		if cfg.Model != "" && cfg.Model != defaultGPTModel {
+			model = cfg.Model
+		}
+		if cfg.SystemInstruction != "" || len(cfg.Examples) > 0 || cfg.Temperature != nil {
+			fmt.Fprintln(os.Stderr, "[WARN] --provider=anthropic does not support system_instruction, examples, or temperature yet; these settings are ignored.")
+		}
+		return &AnthropicProvider{
+			APIKey:          key,
+			APIURL:          "https://api.anthropic.com/v1/messages",
+			Model:           model,
+			MaxOutputTokens: cfg.MaxOutputTokens,
+		}
+	default:
+		log.Fatalf("unsupported provider: %s", cfg.Provider)
+		return nil
+	}
+}
+
+func getGitDiff() (string, error) {
+	stdinStat, _ := os.Stdin.Stat()
+	if (stdinStat.Mode() & os.ModeCharDevice) == 0 {
+		stdinBytes, err := os.ReadFile(os.Stdin.Name())
+		if err != nil {
+			return "", fmt.Errorf("failed to read from stdin: %w", err)
+		}
+
+		diff := strings.TrimSpace(string(stdinBytes))
+		if diff != "" {
+			return diff, nil
+		}
+	}
+	fmt.Fprintf(os.Stderr, "[WARN] No input from stdin, checking for staged changes...\n")
+	cmd := exec.Command("git", "diff", "--staged")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to get git diff: %w", err)
+	}
+
+	diff := strings.TrimSpace(out.String())
+	if diff == "" {
+		return "", fmt.Errorf("no staged changes found")
+	}
+	return diff, nil
+}
+
+func getGitBranch() (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to get git branch: %w", err)
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// extractTicketID pulls a ticket ID out of a branch name using the repo's
+// configured regex (e.g. feature/PROJ-123-add-widget with `[A-Z]+-\d+`).
+func extractTicketID(branch, pattern string) string {
+	if pattern == "" || branch == "" {
+		return ""
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] invalid ticket_id_regex %q: %v\n", pattern, err)
+		return ""
+	}
+	return re.FindString(branch)
+}
+
+const repoConfigFileName = ".dvag-helper.yaml"
+
+// RepoConfig holds the settings loaded from .dvag-helper.yaml, overlaid on
+// $XDG_CONFIG_HOME/dvag-helper/config.yaml. It lets a team commit shared
+// conventions (language, allowed types/scopes, custom prompt templates)
+// alongside the repo instead of relying on compiled-in constants.
+type RepoConfig struct {
+	Provider          string
+	Model             string
+	Language          string
+	Types             []string
+	Scopes            []string
+	ExtraInstructions string
+	TicketIDRegex     string
+	CommitTemplate    string
+	PRTemplate        string
+	SystemInstruction string
+	Examples          []Message
+	Temperature       *float64
+	TopP              *float64
+	TopK              *int
+	MaxOutputTokens   *int
+}
+
+// parseConfigYAML implements the small subset of YAML this tool's config
+// needs (top-level scalars, "key:" lists of "- item" lines, and "key: |"
+// block scalars), which avoids pulling in a YAML dependency for a handful
+// of fields.
+func parseConfigYAML(data []byte) (*RepoConfig, error) {
+	cfg := &RepoConfig{}
+	lines := strings.Split(string(data), "\n")
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			// Stray indented line outside of a list/block we already consumed.
+			continue
+		}
+
+		idx := strings.Index(trimmed, ":")
+		if idx == -1 {
+			continue
+		}
+		key := strings.TrimSpace(trimmed[:idx])
+		rest := strings.TrimSpace(trimmed[idx+1:])
+
+		switch rest {
+		case "|":
+			var block []string
+			for i+1 < len(lines) && (lines[i+1] == "" || strings.HasPrefix(lines[i+1], "  ")) {
+				i++
+				block = append(block, strings.TrimPrefix(lines[i], "  "))
+			}
+			setRepoConfigField(cfg, key, strings.Join(block, "\n"), nil)
+		case "":
+			if key == "examples" {
+				examples, last := parseExamplesList(lines, i+1)
+				cfg.Examples = examples
+				i = last
+				continue
+			}
+			var list []string
+			for i+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i+1]), "- ") {
+				i++
+				list = append(list, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lines[i]), "- ")))
+			}
+			setRepoConfigField(cfg, key, "", list)
+		default:
+			setRepoConfigField(cfg, key, strings.Trim(rest, `"'`), nil)
+		}
+	}
+
+	return cfg, nil
+}
+
+func setRepoConfigField(cfg *RepoConfig, key, value string, list []string) {
+	switch key {
+	case "provider":
+		cfg.Provider = value
+	case "model":
+		cfg.Model = value
+	case "language":
+		cfg.Language = value
+	case "types":
+		cfg.Types = list
+	case "scopes":
+		cfg.Scopes = list
+	case "extra_instructions":
+		cfg.ExtraInstructions = value
+	case "ticket_id_regex":
+		cfg.TicketIDRegex = value
+	case "commit_template":
+		cfg.CommitTemplate = value
+	case "pr_template":
+		cfg.PRTemplate = value
+	case "system_instruction":
+		cfg.SystemInstruction = value
+	case "temperature":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			cfg.Temperature = &f
+		}
+	case "top_p":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			cfg.TopP = &f
+		}
+	case "top_k":
+		if n, err := strconv.Atoi(value); err == nil {
+			cfg.TopK = &n
+		}
+	case "max_tokens":
+		if n, err := strconv.Atoi(value); err == nil {
+			cfg.MaxOutputTokens = &n
+		}
+	}
+}
+
+// parseExamplesList parses the few-shot "examples:" list, where each item is
+// a "- role: ..." entry followed by nested "role:"/"content:" fields (content
+// may itself be a "|" block scalar). It returns the parsed examples and the
+// index of the last line consumed, matching the convention used by the
+// block/list parsing above.
+func parseExamplesList(lines []string, start int) ([]Message, int) {
+	var examples []Message
+	var current *Message
+	baseIndent := -1
+	last := start - 1
+
+	for i := start; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			last = i
+			continue
+		}
+
+		indent := indentOf(line)
+		if baseIndent == -1 {
+			if !strings.HasPrefix(trimmed, "- ") {
+				break
+			}
+			baseIndent = indent
+		}
+		if indent < baseIndent {
+			break
+		}
+
+		if indent == baseIndent {
+			if !strings.HasPrefix(trimmed, "- ") {
+				break
+			}
+			if current != nil {
+				examples = append(examples, *current)
+			}
+			current = &Message{}
+			trimmed = strings.TrimPrefix(trimmed, "- ")
+		}
+
+		idx := strings.Index(trimmed, ":")
+		if idx == -1 {
+			last = i
+			continue
+		}
+		k := strings.TrimSpace(trimmed[:idx])
+		v := strings.TrimSpace(trimmed[idx+1:])
+
+		if v == "|" {
+			var block []string
+			fieldIndent := indent
+			for i+1 < len(lines) {
+				next := lines[i+1]
+				if strings.TrimSpace(next) == "" {
+					block = append(block, "")
+					i++
+					continue
+				}
+				if indentOf(next) <= fieldIndent {
+					break
+				}
+				block = append(block, strings.TrimSpace(next))
+				i++
+			}
+			v = strings.Join(block, "\n")
+		} else {
+			v = strings.Trim(v, `"'`)
+		}
+
+		if current != nil {
+			switch k {
+			case "role":
+				current.Role = v
+			case "content":
+				current.Content = v
+			}
+		}
+		last = i
+	}
+
+	if current != nil {
+		examples = append(examples, *current)
+	}
+	return examples, last
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// mergeRepoConfig overlays non-empty fields of overlay onto base.
+func mergeRepoConfig(base, overlay *RepoConfig) *RepoConfig {
+	merged := *base
+	if overlay.Provider != "" {
+		merged.Provider = overlay.Provider
+	}
+	if overlay.Model != "" {
+		merged.Model = overlay.Model
+	}
+	if overlay.Language != "" {
+		merged.Language = overlay.Language
+	}
+	if len(overlay.Types) > 0 {
+		merged.Types = overlay.Types
+	}
+	if len(overlay.Scopes) > 0 {
+		merged.Scopes = overlay.Scopes
+	}
+	if overlay.ExtraInstructions != "" {
+		merged.ExtraInstructions = overlay.ExtraInstructions
+	}
+	if overlay.TicketIDRegex != "" {
+		merged.TicketIDRegex = overlay.TicketIDRegex
+	}
+	if overlay.CommitTemplate != "" {
+		merged.CommitTemplate = overlay.CommitTemplate
+	}
+	if overlay.PRTemplate != "" {
+		merged.PRTemplate = overlay.PRTemplate
+	}
+	if overlay.SystemInstruction != "" {
+		merged.SystemInstruction = overlay.SystemInstruction
+	}
+	if len(overlay.Examples) > 0 {
+		merged.Examples = overlay.Examples
+	}
+	if overlay.Temperature != nil {
+		merged.Temperature = overlay.Temperature
+	}
+	if overlay.TopP != nil {
+		merged.TopP = overlay.TopP
+	}
+	if overlay.TopK != nil {
+		merged.TopK = overlay.TopK
+	}
+	if overlay.MaxOutputTokens != nil {
+		merged.MaxOutputTokens = overlay.MaxOutputTokens
+	}
+	return &merged
+}
+
+// findGitRoot walks up from startDir looking for a .git directory.
+func findGitRoot(startDir string) (string, bool) {
+	dir := startDir
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// findRepoConfigPath walks up from startDir to the git root (or filesystem
+// root, if no git root is found) looking for repoConfigFileName.
+func findRepoConfigPath(startDir string) string {
+	dir := startDir
+	gitRoot, hasGitRoot := findGitRoot(startDir)
+	for {
+		candidate := filepath.Join(dir, repoConfigFileName)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+		if hasGitRoot && dir == gitRoot {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return ""
+}
+
+func globalConfigPath() string {
+	xdgConfig := os.Getenv("XDG_CONFIG_HOME")
+	if xdgConfig == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		xdgConfig = filepath.Join(home, ".config")
+	}
+	return filepath.Join(xdgConfig, "dvag-helper", "config.yaml")
+}
+
+// loadRepoConfig loads $XDG_CONFIG_HOME/dvag-helper/config.yaml, then
+// overlays the nearest .dvag-helper.yaml found walking up from cwd to the
+// git root. Either file being absent is not an error.
+func loadRepoConfig() (*RepoConfig, error) {
+	merged := &RepoConfig{}
+
+	if path := globalConfigPath(); path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			global, err := parseConfigYAML(data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+			}
+			merged = mergeRepoConfig(merged, global)
+		}
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return merged, nil
+	}
+
+	if path := findRepoConfigPath(cwd); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		repo, err := parseConfigYAML(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		merged = mergeRepoConfig(merged, repo)
+	}
+
+	return merged, nil
+}
+
+const starterConfig = `# dvag-helper configuration
+# Discovered by walking up from the current directory to the git root, and
+# overlaid on $XDG_CONFIG_HOME/dvag-helper/config.yaml.
+
+provider: openai
+model: o4-mini
+language: English
+
+types:
+  - feat
+  - fix
+  - chore
+  - docs
+  - refactor
+  - test
+
+scopes: []
+
+extra_instructions: ""
+
+# Extracted from the current branch name and injected into the prompt.
+ticket_id_regex: '[A-Z]+-\d+'
+
+# system_instruction: "You are a senior engineer writing conventional commits."
+# temperature: 0.2
+# max_tokens: 512
+
+# Few-shot examples of a good commit message for this repo.
+# examples:
+#   - role: user
+#     content: |
+#       diff --git a/foo.go b/foo.go
+#       ...
+#   - role: assistant
+#     content: "feat(api): add pagination to the users endpoint"
+`
+
+// writeStarterConfig creates repoConfigFileName in the current directory.
+func writeStarterConfig() error {
+	if _, err := os.Stat(repoConfigFileName); err == nil {
+		return fmt.Errorf("%s already exists", repoConfigFileName)
+	}
+	return os.WriteFile(repoConfigFileName, []byte(starterConfig), 0644)
+}
+
+// estimateTokenCount gives a rough token estimate (~4 characters per token)
+// good enough to decide whether a diff needs to be chunked.
+func estimateTokenCount(s string) int {
+	return len(s) / 4
+}
+
+// splitDiffIntoHunks splits a unified diff into its per-file hunks on
+// "diff --git " boundaries, so each hunk can be summarized independently.
+func splitDiffIntoHunks(diff string) []string {
+	parts := strings.Split(diff, "\ndiff --git ")
+	hunks := make([]string, 0, len(parts))
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+		if i > 0 {
+			part = "diff --git " + part
+		}
+		hunks = append(hunks, part)
+	}
+	return hunks
+}
+
+// cosineSimilarity measures how semantically close two embedding vectors are.
+func cosineSimilarity(a, b []float32) float32 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return float32(dot / (math.Sqrt(normA) * math.Sqrt(normB)))
+}
+
+// binPackHunks greedily groups diff hunks into context-sized bins. When
+// embeddings are available, a hunk is added to whichever bin its most
+// recently added hunk is most similar to, keeping semantically related
+// changes together; otherwise hunks are simply packed in order.
+func binPackHunks(hunks []string, embeddings [][]float32, maxTokens int) [][]string {
+	type bin struct {
+		hunks   []string
+		tokens  int
+		lastEmb []float32
+	}
+	var bins []*bin
+
+	for i, hunk := range hunks {
+		tokens := estimateTokenCount(hunk)
+
+		var best *bin
+		bestSimilarity := float32(-1)
+		for _, b := range bins {
+			if b.tokens+tokens > maxTokens {
+				continue
+			}
+			if embeddings == nil {
+				best = b
+				break
+			}
+			if sim := cosineSimilarity(embeddings[i], b.lastEmb); best == nil || sim > bestSimilarity {
+				best, bestSimilarity = b, sim
+			}
+		}
+
+		if best == nil {
+			best = &bin{}
+			bins = append(bins, best)
+		}
+		best.hunks = append(best.hunks, hunk)
+		best.tokens += tokens
+		if embeddings != nil {
+			best.lastEmb = embeddings[i]
+		}
+	}
+
+	groups := make([][]string, len(bins))
+	for i, b := range bins {
+		groups[i] = b.hunks
+	}
+	return groups
+}
+
+func generateMessage(cfg *config, gitDiff string) (string, error) {
+	var tmplText string
 
 	switch cfg.Mode {
 	case ModeCommit:
-		prompt = fmt.Sprintf(commitPromptTemplate, gitDiff)
+		tmplText = defaultCommitPromptTemplate
+		if cfg.CommitTemplate != "" {
+			tmplText = cfg.CommitTemplate
+		}
 	case ModePR:
-		prompt = fmt.Sprintf(prPromptTemplate, gitDiff)
+		tmplText = defaultPRPromptTemplate
+		if cfg.PRTemplate != "" {
+			tmplText = cfg.PRTemplate
+		}
 	default:
 		return "", fmt.Errorf("invalid mode: %s", cfg.Mode)
 	}
 
+	data := templateData{
+		Branch:   cfg.Branch,
+		Types:    cfg.Types,
+		Scopes:   cfg.Scopes,
+		TicketID: cfg.TicketID,
+		Language: cfg.Language,
+		Extra:    cfg.ExtraInstructions,
+	}
+
+	renderChunk := func(diff string) (string, error) {
+		chunkData := data
+		chunkData.Diff = diff
+		return renderPrompt(tmplText, chunkData)
+	}
+
 	// Create a provider based on the configuration
 	provider := NewProvider(cfg)
 
-	// Use the provider to generate the message
-	return provider.GenerateMessage(prompt)
+	if estimateTokenCount(gitDiff) <= cfg.MaxDiffTokens {
+		prompt, err := renderChunk(gitDiff)
+		if err != nil {
+			return "", err
+		}
+		return provider.GenerateMessage(prompt)
+	}
+
+	fmt.Fprintf(os.Stderr, "[INFO] diff exceeds --max-diff-tokens (%d), splitting into chunks\n", cfg.MaxDiffTokens)
+
+	hunks := splitDiffIntoHunks(gitDiff)
+
+	var embeddings [][]float32
+	if cfg.Mode == ModePR {
+		var err error
+		embeddings, err = provider.Embed(hunks)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[WARN] failed to embed diff hunks, falling back to sequential chunking: %v\n", err)
+			embeddings = nil
+		}
+	}
+	groups := binPackHunks(hunks, embeddings, cfg.MaxDiffTokens)
+
+	summaries := make([]string, 0, len(groups))
+	for i, group := range groups {
+		prompt, err := renderChunk(strings.Join(group, "\n"))
+		if err != nil {
+			return "", err
+		}
+		summary, err := provider.GenerateMessage(prompt)
+		if err != nil {
+			return "", fmt.Errorf("failed to summarize diff chunk %d/%d: %w", i+1, len(groups), err)
+		}
+		summaries = append(summaries, summary)
+	}
+
+	if len(summaries) == 1 {
+		return summaries[0], nil
+	}
+
+	reduceTmplText := defaultReduceCommitPromptTemplate
+	if cfg.Mode == ModePR {
+		reduceTmplText = defaultReducePRPromptTemplate
+	}
+	reduceData := data
+	reduceData.Diff = strings.Join(summaries, "\n---\n")
+	reducePrompt, err := renderPrompt(reduceTmplText, reduceData)
+	if err != nil {
+		return "", err
+	}
+	return provider.GenerateMessage(reducePrompt)
+}
+
+// streamMessage renders the commit/PR prompt for the full diff and streams
+// the provider's response to out token-by-token as it arrives, returning the
+// accumulated message once the stream ends. Unlike generateMessage, it does
+// not chunk oversized diffs; --max-diff-tokens is ignored in --stream mode.
+func streamMessage(cfg *config, gitDiff string, out io.Writer) (string, error) {
+	var tmplText string
+
+	switch cfg.Mode {
+	case ModeCommit:
+		tmplText = defaultCommitPromptTemplate
+		if cfg.CommitTemplate != "" {
+			tmplText = cfg.CommitTemplate
+		}
+	case ModePR:
+		tmplText = defaultPRPromptTemplate
+		if cfg.PRTemplate != "" {
+			tmplText = cfg.PRTemplate
+		}
+	default:
+		return "", fmt.Errorf("invalid mode: %s", cfg.Mode)
+	}
+
+	if estimateTokenCount(gitDiff) > cfg.MaxDiffTokens {
+		fmt.Fprintf(os.Stderr, "[INFO] diff exceeds --max-diff-tokens (%d); --stream sends it in one request instead of chunking\n", cfg.MaxDiffTokens)
+	}
+
+	prompt, err := renderPrompt(tmplText, templateData{
+		Diff:     gitDiff,
+		Branch:   cfg.Branch,
+		Types:    cfg.Types,
+		Scopes:   cfg.Scopes,
+		TicketID: cfg.TicketID,
+		Language: cfg.Language,
+		Extra:    cfg.ExtraInstructions,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	provider := NewProvider(cfg)
+	tokens, errs := provider.GenerateMessageStream(prompt)
+
+	var full strings.Builder
+	for token := range tokens {
+		full.WriteString(token)
+		fmt.Fprint(out, token)
+	}
+	if err := <-errs; err != nil {
+		return "", err
+	}
+	return full.String(), nil
 }
 
 // printUsage displays detailed help information about the application
@@ -390,6 +1880,9 @@ func printUsage() {
 	fmt.Println("\nSUPPORTED PROVIDERS:")
 	fmt.Printf("  %s                 OpenAI API (default)\n", OpenAI)
 	fmt.Printf("  %s                 Google Gemini\n", Google)
+	fmt.Printf("  %s      Any OpenAI-compatible endpoint (--base-url or OPENAI_COMPATIBLE_BASE_URL)\n", OpenAICompatible)
+	fmt.Printf("  %s                 Shortcut for --provider=openai-compatible, defaults --base-url to http://localhost:11434/v1\n", Ollama)
+	fmt.Printf("  %s              Anthropic Claude\n", Anthropic)
 	// Add more providers here as they are implemented
 
 	// Examples
@@ -400,8 +1893,17 @@ func printUsage() {
 	fmt.Printf("  git diff | %s --pr      # Generate PR description from piped git diff\n", appName)
 	fmt.Printf("  %s --model=\"o4-mini\"    # Use a specific AI model\n", appName)
 	fmt.Printf("  %s --provider=\"openai\"  # Use a specific AI provider\n", appName)
+	fmt.Printf("  %s --init               # Write a starter %s in this directory\n", appName, repoConfigFileName)
+	fmt.Printf("  %s --temperature=0.2    # Use a lower sampling temperature\n", appName)
+	fmt.Printf("  %s --stream             # Print the response to stdout as it is generated\n", appName)
 	fmt.Printf("  %s --help               # Show this help message\n", appName)
 
+	fmt.Println("\nCONFIGURATION:")
+	fmt.Printf("  %s is discovered by walking up from the current directory to the git root,\n", repoConfigFileName)
+	fmt.Println("  overlaid on $XDG_CONFIG_HOME/dvag-helper/config.yaml. It configures the default")
+	fmt.Println("  provider/model, output language, allowed conventional-commit types/scopes,")
+	fmt.Println("  custom prompt templates, and a ticket-ID regex extracted from the branch name.")
+
 	fmt.Println("\nENVIRONMENT VARIABLES:")
 	keySet := "unset"
 	if os.Getenv("OPENAI_API_KEY") != "" {
@@ -414,21 +1916,35 @@ func printUsage() {
 		keySet = "set"
 	}
 	fmt.Printf("  GEMINI_API_KEY: <%s> (required for the provider: %s)\n", keySet, Google)
+
+	keySet = "unset"
+	if os.Getenv("ANTHROPIC_API_KEY") != "" {
+		keySet = "set"
+	}
+	fmt.Printf("  ANTHROPIC_API_KEY: <%s> (required for the provider: %s)\n", keySet, Anthropic)
 }
 
 func parseFlags() *config {
 	cfg := &config{
-		Model:    defaultGPTModel,
-		Mode:     ModeCommit,
-		Provider: ProviderEnumFromString(defaultProvider),
+		Model:         defaultGPTModel,
+		Mode:          ModeCommit,
+		Provider:      ProviderEnumFromString(defaultProvider),
+		MaxDiffTokens: defaultMaxDiffTokens,
 	}
 
 	helpFlag := flag.Bool("help", false, "Display usage information")
 	modelFlag := flag.String("model", defaultGPTModel, "AI model to use for generating messages")
 
 	var providerFlag string
-	flag.StringVar(&providerFlag, "provider", defaultProvider, "AI provider to use (openai, google etc.)")
-	flag.StringVar(&providerFlag, "p", defaultProvider, "AI provider to use (openai, google, etc.)")
+	flag.StringVar(&providerFlag, "provider", defaultProvider, "AI provider to use (openai, google, openai-compatible, ollama, anthropic etc.)")
+	flag.StringVar(&providerFlag, "p", defaultProvider, "AI provider to use (openai, google, openai-compatible, ollama, anthropic etc.)")
+
+	baseURLFlag := flag.String("base-url", "", "Base URL for the openai-compatible/ollama providers")
+	maxDiffTokensFlag := flag.Int("max-diff-tokens", defaultMaxDiffTokens, "Maximum estimated diff size, in tokens, before it is split into chunks")
+	initFlag := flag.Bool("init", false, "Write a starter "+repoConfigFileName+" in the current directory and exit")
+	temperatureFlag := flag.Float64("temperature", 0, "Sampling temperature for the model (provider default if unset)")
+	maxTokensFlag := flag.Int("max-tokens", 0, "Maximum output tokens for the model (provider default if unset)")
+	streamFlag := flag.Bool("stream", false, "Stream the response to stdout as it is generated, instead of waiting for the full message")
 
 	commitFlag := flag.Bool("cm", false, "Generate a commit message (default mode)")
 	prFlag := flag.Bool("pr", false, "Generate a pull request description")
@@ -440,6 +1956,14 @@ func parseFlags() *config {
 		os.Exit(0)
 	}
 
+	if *initFlag {
+		if err := writeStarterConfig(); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Wrote starter config to %s\n", repoConfigFileName)
+		os.Exit(0)
+	}
+
 	if *prFlag && *commitFlag {
 		fmt.Fprintln(os.Stderr, "[WARN] Both --pr and --cm flags specified. Using --cm mode.")
 		cfg.Mode = ModeCommit
@@ -449,9 +1973,58 @@ func parseFlags() *config {
 		cfg.Mode = ModeCommit
 	}
 
-	cfg.Model = *modelFlag
+	// CLI flags take precedence over .dvag-helper.yaml, which takes
+	// precedence over built-in defaults.
+	repoCfg, err := loadRepoConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] failed to load %s: %v\n", repoConfigFileName, err)
+		repoCfg = &RepoConfig{}
+	}
+
+	explicit := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	if !explicit["model"] && repoCfg.Model != "" {
+		cfg.Model = repoCfg.Model
+	} else {
+		cfg.Model = *modelFlag
+	}
+
+	if !explicit["provider"] && !explicit["p"] && repoCfg.Provider != "" {
+		providerFlag = repoCfg.Provider
+	}
 	cfg.Provider = ProviderEnumFromString(providerFlag)
 
+	cfg.BaseURL = *baseURLFlag
+	cfg.MaxDiffTokens = *maxDiffTokensFlag
+	cfg.Language = repoCfg.Language
+	cfg.Types = repoCfg.Types
+	cfg.Scopes = repoCfg.Scopes
+	cfg.ExtraInstructions = repoCfg.ExtraInstructions
+	cfg.CommitTemplate = repoCfg.CommitTemplate
+	cfg.PRTemplate = repoCfg.PRTemplate
+	cfg.SystemInstruction = repoCfg.SystemInstruction
+	cfg.Examples = repoCfg.Examples
+	cfg.TopP = repoCfg.TopP
+	cfg.TopK = repoCfg.TopK
+
+	cfg.Temperature = repoCfg.Temperature
+	if explicit["temperature"] {
+		cfg.Temperature = temperatureFlag
+	}
+
+	cfg.MaxOutputTokens = repoCfg.MaxOutputTokens
+	if explicit["max-tokens"] {
+		cfg.MaxOutputTokens = maxTokensFlag
+	}
+
+	cfg.Stream = *streamFlag
+
+	if branch, err := getGitBranch(); err == nil {
+		cfg.Branch = branch
+		cfg.TicketID = extractTicketID(branch, repoCfg.TicketIDRegex)
+	}
+
 	return cfg
 }
 
@@ -463,6 +2036,14 @@ func main() {
 		log.Fatal("Error getting git diff:", err)
 	}
 
+	if cfg.Stream {
+		if _, err := streamMessage(cfg, gitDiff, os.Stdout); err != nil {
+			log.Fatal("Error generating message:", err)
+		}
+		fmt.Println()
+		return
+	}
+
 	generatedMessage, err := generateMessage(cfg, gitDiff)
 	if err != nil {
 		log.Fatal("Error generating message:", err)